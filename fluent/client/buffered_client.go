@@ -1,6 +1,9 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"sync"
@@ -9,6 +12,8 @@ import (
 
 	"github.com/IBM/fluent-forward-go/fluent/protocol"
 	"github.com/tinylib/msgp/msgp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -20,17 +25,113 @@ type BufferedClient struct {
 	Client
 	bufferSize    int
 	flushInterval time.Duration
-	writer        *msgp.Writer
-	ticker        *time.Ticker
-	mutex         sync.Mutex
-	stop          chan struct{}
-	done          chan struct{}
+	// writer holds the *msgp.Writer bound to the current connection. It's
+	// written to concurrently by connect() (first connect) and Reconnect()
+	// (after a dropped connection) while writerLoop may be reading it at the
+	// same time, so it's swapped via atomic.Value rather than assigned
+	// directly - see currentWriter().
+	writer atomic.Value
+	ticker *time.Ticker
+	stop   chan struct{}
+	done   chan struct{}
+
+	channels        map[string]*sendChannel
+	channelOrder    []string
+	channelsMu      sync.RWMutex
+	defaultChannel  *sendChannel
+	overflowPolicy  SendOverflowPolicy
+	enqueueTimeout  time.Duration
+	highWaterMark   int
+	shutdownTimeout time.Duration
+	// unflushedBytes tracks bytes written to bc.writer since the last flush,
+	// i.e. the real buffer_occupancy the metrics gauge reports. Written by
+	// writerLoop, and reset to 0 by connect()/Reconnect() when the writer is
+	// replaced, so it's accessed atomically; the gauge's callback also reads
+	// it from whatever goroutine the metrics reader runs on.
+	unflushedBytes int64
+
+	walOpts   WALOptions
+	wal       *bufferedWAL
+	walNotify chan struct{}
+	walStop   chan struct{}
+	walDone   chan struct{}
+
+	// walAcks tracks, for WAL entries carrying a RequireAck chunk, the
+	// channel a blocked Send is waiting on. walShipLoop resolves and removes
+	// the entry once checkAck returns, whether it succeeds or fails.
+	walAcksMu sync.Mutex
+	walAcks   map[uint64]chan error
+
+	tracerProvider trace.TracerProvider
+	metrics        *clientMetrics
+
+	rateLimiter *rateLimiter
+
+	retryPolicy   RetryPolicy
+	breaker       *circuitBreaker
+	reconnectStop chan struct{}
+	reconnectWG   sync.WaitGroup // tracks the in-flight reconnectLoop goroutine, if any
+	state         int32          // ClientState, accessed via atomic
+	stateSubs     []chan ClientState
+	stateSubsMu   sync.Mutex
 }
 
 type BufferedClientConnectionOptions struct {
 	ConnectionOptions
 	BufferSize    int
 	FlushInterval time.Duration
+	WAL           WALOptions
+
+	// SendQueueCapacity bounds the number of encoded frames awaiting the
+	// single writer goroutine. Defaults to 1024.
+	SendQueueCapacity int
+	// HighWaterMark triggers a flush once this many unflushed bytes have
+	// been written, independent of FlushInterval. Defaults to BufferSize.
+	HighWaterMark int
+	// OverflowPolicy decides what Send/SendRaw do when the send queue is
+	// full. Defaults to BlockWithTimeout.
+	OverflowPolicy SendOverflowPolicy
+	// EnqueueTimeout bounds how long BlockWithTimeout waits for queue room.
+	// Defaults to ConnectionTimeout.
+	EnqueueTimeout time.Duration
+	// ShutdownTimeout bounds how long Stop() waits for the send queue to
+	// drain before giving up. Defaults to 5s.
+	ShutdownTimeout time.Duration
+
+	// Channels declares additional named send channels beyond the implicit
+	// "default" one used by Send/SendRaw/SendForward/SendCompressed. Use
+	// SendOn/SendMessageOn to send on them. The writer goroutine schedules
+	// across channels with weighted round robin, keyed by Priority.
+	Channels []ChannelDescriptor
+
+	// TracerProvider, when set, enables OpenTelemetry spans around
+	// Send/SendRaw/SendForward/SendCompressed/Sync. A nil provider disables
+	// tracing entirely.
+	TracerProvider trace.TracerProvider
+	// MeterProvider, when set, enables per-client OpenTelemetry metrics
+	// (bytes written, flush latency, buffer occupancy, ack-wait latency,
+	// reconnect count). A nil provider disables metrics entirely.
+	MeterProvider metric.MeterProvider
+
+	// BytesPerSecond and BytesBurst cap outbound bytes via a token bucket.
+	// BytesBurst defaults to BytesPerSecond when unset.
+	BytesPerSecond float64
+	BytesBurst     int
+	// MessagesPerSecond and MessagesBurst cap outbound messages the same
+	// way. MessagesBurst defaults to MessagesPerSecond when unset.
+	MessagesPerSecond float64
+	MessagesBurst     int
+
+	// RetryPolicy decides how long to wait between reconnect attempts after
+	// a write/flush failure. Defaults to exponential backoff with jitter.
+	RetryPolicy RetryPolicy
+	// CircuitBreakerThreshold is the number of consecutive reconnect
+	// failures after which the breaker opens and further attempts are
+	// withheld until CircuitBreakerOpenDuration has elapsed. Defaults to 5.
+	CircuitBreakerThreshold int
+	// CircuitBreakerOpenDuration is how long the breaker stays open before
+	// allowing a single half-open probe. Defaults to 30s.
+	CircuitBreakerOpenDuration time.Duration
 }
 
 func NewBufferedClient(opts BufferedClientConnectionOptions) *BufferedClient {
@@ -54,25 +155,33 @@ func NewBufferedClient(opts BufferedClientConnectionOptions) *BufferedClient {
 		opts.FlushInterval = defaultFlushInterval
 	}
 
-	go func() {
-		ticker := time.NewTicker(10 * time.Second)
+	if opts.SendQueueCapacity == 0 {
+		opts.SendQueueCapacity = defaultSendQueueCapacity
+	}
 
-		for {
-			select {
-			case <-ticker.C:
-				bytes := atomic.LoadInt64(&totalBytesWritten)
-				kilobytes := bytes / 1024
-				totalNs := atomic.LoadInt64(&totalWriteTimeNs)
-				totalMs := atomic.LoadInt64(&totalWriteTimeMs)
-				count := atomic.LoadInt64(&writeCount)
-				avgTimeNs := float64(totalNs) / float64(count) // Average time per write in nanoseconds
-				avgTimeMs := float64(totalMs) / float64(count) // Average time per write in milliseconds
+	if opts.HighWaterMark == 0 {
+		opts.HighWaterMark = opts.BufferSize
+	}
 
-				fmt.Printf("**Buffered Client** Total Bytes Written: %d, Total Kilobytes Written: %d, Average Time per Write: %.2f ns, %.2f ms, Total writes: %d \n", bytes, kilobytes, avgTimeNs, avgTimeMs, count)
+	if opts.EnqueueTimeout == 0 {
+		opts.EnqueueTimeout = opts.ConnectionTimeout
+	}
 
-			}
-		}
-	}()
+	if opts.ShutdownTimeout == 0 {
+		opts.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if opts.RetryPolicy == nil {
+		opts.RetryPolicy = defaultRetryPolicy()
+	}
+
+	if opts.CircuitBreakerThreshold == 0 {
+		opts.CircuitBreakerThreshold = defaultCircuitBreakerThreshold
+	}
+
+	if opts.CircuitBreakerOpenDuration == 0 {
+		opts.CircuitBreakerOpenDuration = defaultCircuitBreakerOpenDuration
+	}
 
 	bc := &BufferedClient{
 		Client: Client{
@@ -81,47 +190,69 @@ func NewBufferedClient(opts BufferedClientConnectionOptions) *BufferedClient {
 			RequireAck:        opts.RequireAck,
 			Timeout:           opts.ConnectionTimeout,
 		},
-		bufferSize:    opts.BufferSize,
-		flushInterval: opts.FlushInterval,
-		writer:        nil,
-		ticker:        time.NewTicker(opts.FlushInterval),
-		mutex:         sync.Mutex{},
-		stop:          make(chan struct{}),
-		done:          make(chan struct{}),
+		bufferSize:      opts.BufferSize,
+		flushInterval:   opts.FlushInterval,
+		ticker:          time.NewTicker(opts.FlushInterval),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+		overflowPolicy:  opts.OverflowPolicy,
+		enqueueTimeout:  opts.EnqueueTimeout,
+		highWaterMark:   opts.HighWaterMark,
+		shutdownTimeout: opts.ShutdownTimeout,
+		walOpts:         opts.WAL,
+		tracerProvider:  opts.TracerProvider,
+		retryPolicy:     opts.RetryPolicy,
+		breaker:         newCircuitBreaker(opts.CircuitBreakerThreshold, opts.CircuitBreakerOpenDuration),
+		reconnectStop:   make(chan struct{}),
 	}
 
+	bc.registerChannels(opts)
+	bc.metrics = newClientMetrics(opts.MeterProvider, bc)
+	bc.rateLimiter = newRateLimiter(opts)
+
 	return bc
 
 }
 
-func (bc *BufferedClient) flushLoop() {
-	defer close(bc.done)
-
-	for {
-		select {
-		case <-bc.ticker.C:
-			_ = bc.Sync() // ignore error?
-		case <-bc.stop:
-			return
-		}
-	}
+// walEnabled reports whether this client should durably log chunks to disk
+// before shipping them, rather than writing straight to the connection.
+func (bc *BufferedClient) walEnabled() bool {
+	return bc.walOpts.Dir != ""
 }
 
+// Sync flushes any unwritten bytes, waiting for the writer goroutine to
+// act on it rather than flushing directly (see channels.go/queue.go).
 func (bc *BufferedClient) Sync() error {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
+	_, span := bc.startSpan(context.Background(), "BufferedClient.Sync", "", 0, 0)
+	defer span.End()
 
-	return bc.writer.Flush()
+	err := bc.enqueueFlush()
+	if err != nil {
+		span.RecordError(err)
+	}
 
+	return err
 }
 
 func (bc *BufferedClient) Stop() {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
-
 	bc.ticker.Stop()
-	close(bc.stop) // tell flushLoop to stop
-	<-bc.done      // and wait until it has
+	close(bc.stop)          // tell writerLoop to drain the queue and stop
+	close(bc.reconnectStop) // and give up on any in-flight reconnect attempt
+	<-bc.done               // and wait until it has
+
+	// Join reconnectLoop before returning: a goroutine still blocked in
+	// Reconnect() or sleepOrStop would otherwise keep running against
+	// bc.reconnectStop/bc.wal fields that connect() is free to reassign the
+	// moment Stop() returns, which is both a stale reconnect attempt and an
+	// unsynchronized read/write race on those fields.
+	bc.reconnectWG.Wait()
+
+	if bc.wal != nil {
+		close(bc.walStop)
+		<-bc.walDone
+		_ = bc.wal.Close()
+		bc.wal = nil // connect() re-opens the WAL if Connect() is called again
+	}
 }
 
 // Connect initializes the Session and Connection objects by opening
@@ -142,49 +273,93 @@ func (bc *BufferedClient) Disconnect() error {
 	return bc.Client.Disconnect()
 }
 
+// Reconnect redials and rebuilds the writer bound to the new connection.
+// The writer is swapped in atomically (see currentWriter()) rather than via
+// a fresh writerLoop, since writerLoop keeps running the whole time - a
+// reconnect only replaces what it's writing to, not the goroutine itself.
 func (bc *BufferedClient) Reconnect() error {
-	return bc.Client.Reconnect()
+	if err := bc.Client.Reconnect(); err != nil {
+		return err
+	}
+
+	bc.writer.Store(msgp.NewWriterSize(bc.Client.session.Connection, bc.bufferSize))
+	atomic.StoreInt64(&bc.unflushedBytes, 0)
+
+	bc.metrics.addReconnect(context.Background())
+
+	return nil
+}
+
+// currentWriter returns the *msgp.Writer bound to the current connection.
+// Only writerLoop (via processFrame/flushWriter) should call this to
+// actually write; Reconnect swaps the value out concurrently.
+func (bc *BufferedClient) currentWriter() *msgp.Writer {
+	return bc.writer.Load().(*msgp.Writer)
 }
 
 func (bc *BufferedClient) SendRaw(m []byte) error {
+	_, span := bc.startSpan(context.Background(), "BufferedClient.SendRaw", "", 0, len(m))
+	defer span.End()
+
 	if bc.session == nil {
-		return errors.New("no active session")
+		err := errors.New("no active session")
+		span.RecordError(err)
+
+		return err
 	}
 
 	bc.sessionLock.RLock()
 	defer bc.sessionLock.RUnlock()
 
 	if !bc.session.TransportPhase {
-		return errors.New("session handshake not completed")
+		err := errors.New("session handshake not completed")
+		span.RecordError(err)
+
+		return err
 	}
 
-	// Start timing the write operation
-	startTime := time.Now()
+	if err := bc.rateLimiter.wait(len(m), bc.Client.Timeout); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if bc.walEnabled() {
+		if _, err := bc.walAppend("", m); err != nil {
+			span.RecordError(err)
+			return err
+		}
+
+		return nil
+	}
 
-	// Write data to the buffer
-	n, err := bc.writer.Write(m)
-	// Update metrics
-	durationNs := time.Since(startTime).Nanoseconds() // Duration in nanoseconds
-	durationMs := durationNs / 1e6                    // Convert to milliseconds
-	atomic.AddInt64(&totalBytesWritten, int64(n))
-	atomic.AddInt64(&totalWriteTimeNs, durationNs)
-	atomic.AddInt64(&totalWriteTimeMs, durationMs)
-	atomic.AddInt64(&writeCount, 1)
+	err := bc.enqueueWrite(m, false)
+	if err != nil {
+		span.RecordError(err)
+	}
 
 	return err
 }
 
 func (bc *BufferedClient) Send(e protocol.ChunkEncoder) error {
+	ctx, span := bc.startSpan(context.Background(), "BufferedClient.Send", "", 1, 0)
+	defer span.End()
+
 	bc.sessionLock.RLock()
 	defer bc.sessionLock.RUnlock()
 
 	if bc.session == nil {
-		return errors.New("no active session")
+		err := errors.New("no active session")
+		span.RecordError(err)
+
+		return err
 	}
 
 	// Check if the session handshake is completed
 	if !bc.session.TransportPhase {
-		return errors.New("session handshake not completed")
+		err := errors.New("session handshake not completed")
+		span.RecordError(err)
+
+		return err
 	}
 
 	// Handle RequireAck scenario
@@ -193,6 +368,7 @@ func (bc *BufferedClient) Send(e protocol.ChunkEncoder) error {
 	if bc.Client.RequireAck {
 		chunk, err = e.Chunk()
 		if err != nil {
+			span.RecordError(err)
 			return err
 		}
 
@@ -200,48 +376,230 @@ func (bc *BufferedClient) Send(e protocol.ChunkEncoder) error {
 		defer bc.Client.ackLock.Unlock()
 	}
 
-	// Directly encode and handle errors without extra variable
-	// if err = msgp.Encode(bc.session.Connection, e); err != nil {
-	if err = e.EncodeMsg(bc.writer); err != nil {
+	data, err := encodeToBytes(e)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := bc.rateLimiter.wait(len(data), bc.Client.Timeout); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if bc.walEnabled() {
+		ackCh, err := bc.walAppend(chunk, data)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+
+		if ackCh == nil {
+			return nil
+		}
+
+		// A RequireAck send must wait for the shipper to actually deliver
+		// and confirm this entry before Send reports success, exactly as
+		// the non-WAL path waits on checkAck below.
+		ackStart := time.Now()
+		err = <-ackCh
+		bc.metrics.recordAckWaitLatency(ctx, float64(time.Since(ackStart).Milliseconds()))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		return err
+	}
+
+	// A RequireAck send must hit the wire before we wait for the ack, so
+	// force a flush for this frame rather than waiting for the next tick.
+	if err := bc.enqueueWrite(data, bc.Client.RequireAck); err != nil {
+		span.RecordError(err)
 		return err
 	}
 
 	// Only proceed to checkAck if RequireAck is true
 	if bc.Client.RequireAck {
-		return bc.checkAck(chunk)
+		ackStart := time.Now()
+		err := bc.checkAck(chunk)
+
+		bc.metrics.recordAckWaitLatency(ctx, float64(time.Since(ackStart).Milliseconds()))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		return err
 	}
 
 	return nil
 }
 
 func (bc *BufferedClient) SendCompressed(tag string, entries protocol.EntryList) error {
-	return bc.Client.SendCompressed(tag, entries)
+	_, span := bc.startSpan(context.Background(), "BufferedClient.SendCompressed", tag, len(entries), 0)
+	defer span.End()
+
+	if err := bc.rateLimiter.wait(0, bc.Client.Timeout); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	err := bc.Client.SendCompressed(tag, entries)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
 }
 
 func (bc *BufferedClient) SendCompressedFromBytes(tag string, entries []byte) error {
+	if err := bc.rateLimiter.wait(len(entries), bc.Client.Timeout); err != nil {
+		return err
+	}
+
 	return bc.Client.SendCompressedFromBytes(tag, entries)
 }
 
 func (bc *BufferedClient) SendForward(tag string, entries protocol.EntryList) error {
-	return bc.Client.SendForward(tag, entries)
+	_, span := bc.startSpan(context.Background(), "BufferedClient.SendForward", tag, len(entries), 0)
+	defer span.End()
+
+	if err := bc.rateLimiter.wait(0, bc.Client.Timeout); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	err := bc.Client.SendForward(tag, entries)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
 }
 
 func (bc *BufferedClient) SendMessage(tag string, record interface{}) error {
+	if err := bc.rateLimiter.wait(0, bc.Client.Timeout); err != nil {
+		return err
+	}
+
 	return bc.Client.SendMessage(tag, record)
 }
 
 func (bc *BufferedClient) SendMessageExt(tag string, record interface{}) error {
+	if err := bc.rateLimiter.wait(0, bc.Client.Timeout); err != nil {
+		return err
+	}
+
 	return bc.Client.SendMessageExt(tag, record)
 }
 
 func (bc *BufferedClient) SendPacked(tag string, entries protocol.EntryList) error {
+	if err := bc.rateLimiter.wait(0, bc.Client.Timeout); err != nil {
+		return err
+	}
+
 	return bc.Client.SendPacked(tag, entries)
 }
 
 func (bc *BufferedClient) SendPackedFromBytes(tag string, entries []byte) error {
+	if err := bc.rateLimiter.wait(len(entries), bc.Client.Timeout); err != nil {
+		return err
+	}
+
 	return bc.Client.SendPackedFromBytes(tag, entries)
 }
 
+// walAppend durably logs data before notifying the shipper, so that a
+// crash between the append and the eventual write is recoverable on the
+// next Connect. When chunk is non-empty (a RequireAck send), the returned
+// channel receives the ack's eventual outcome once walShipLoop delivers
+// this entry; callers that don't need an ack (SendRaw, or Send without
+// RequireAck) get a nil channel back and can return as soon as the entry
+// is durably logged.
+func (bc *BufferedClient) walAppend(chunk string, data []byte) (<-chan error, error) {
+	seq, err := bc.wal.append(encodeWALFrame(chunk, data))
+	if err != nil {
+		return nil, err
+	}
+
+	var ackCh chan error
+	if chunk != "" {
+		ackCh = make(chan error, 1)
+
+		bc.walAcksMu.Lock()
+		bc.walAcks[seq] = ackCh
+		bc.walAcksMu.Unlock()
+	}
+
+	select {
+	case bc.walNotify <- struct{}{}:
+	default:
+	}
+
+	return ackCh, nil
+}
+
+// resolveWALAck delivers a shipped entry's ack outcome to whichever Send
+// call registered a waiter for it in walAppend, if any. It's a no-op for
+// entries that never carried a RequireAck chunk, and for entries a waiter
+// already heard back about (e.g. via a prior evict).
+func (bc *BufferedClient) resolveWALAck(seq uint64, err error) {
+	bc.walAcksMu.Lock()
+	ch, ok := bc.walAcks[seq]
+	if ok {
+		delete(bc.walAcks, seq)
+	}
+	bc.walAcksMu.Unlock()
+
+	if ok {
+		ch <- err
+	}
+}
+
+// walFrameChunkLen is the size, in bytes, of the length prefix encodeWALFrame
+// stores ahead of the optional ack chunk id.
+const walFrameChunkLen = 2
+
+// encodeWALFrame packs an optional ack chunk id alongside the encoded
+// fluent-forward frame into the single []byte the WAL stores. Keeping this
+// encoding here (rather than in wal.go) lets bufferedWAL stay a generic,
+// fluent-forward-agnostic byte log.
+func encodeWALFrame(chunk string, data []byte) []byte {
+	buf := make([]byte, walFrameChunkLen+len(chunk)+len(data))
+	binary.BigEndian.PutUint16(buf[:walFrameChunkLen], uint16(len(chunk)))
+	copy(buf[walFrameChunkLen:], chunk)
+	copy(buf[walFrameChunkLen+len(chunk):], data)
+
+	return buf
+}
+
+// decodeWALFrame reverses encodeWALFrame.
+func decodeWALFrame(raw []byte) (chunk string, data []byte) {
+	n := binary.BigEndian.Uint16(raw[:walFrameChunkLen])
+	chunk = string(raw[walFrameChunkLen : walFrameChunkLen+n])
+	data = raw[walFrameChunkLen+n:]
+
+	return chunk, data
+}
+
+// encodeToBytes runs a ChunkEncoder through a throwaway msgp.Writer so the
+// result can be handed to the WAL, which only deals in raw bytes.
+func encodeToBytes(e protocol.ChunkEncoder) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := msgp.NewWriter(&buf)
+	if err := e.EncodeMsg(w); err != nil {
+		return nil, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 func (bc *BufferedClient) connect() error {
 	err := bc.Client.connect()
 	if err != nil {
@@ -249,13 +607,114 @@ func (bc *BufferedClient) connect() error {
 	}
 
 	// Create a buffered writer with a specified buffer size
-	bc.writer = msgp.NewWriterSize(bc.Client.session.Connection, bc.bufferSize)
+	bc.writer.Store(msgp.NewWriterSize(bc.Client.session.Connection, bc.bufferSize))
+
+	// Recreate the stop/done/reconnectStop signals on every Connect(), since
+	// Stop() consumes them and a client may Connect() again afterwards. The
+	// channel queues are created once, in NewBufferedClient.
+	bc.stop = make(chan struct{})
+	bc.done = make(chan struct{})
+	bc.reconnectStop = make(chan struct{})
+	atomic.StoreInt64(&bc.unflushedBytes, 0)
+	atomic.StoreInt32(&bc.state, int32(StateConnected))
+
+	go bc.writerLoop()
+
+	if bc.walEnabled() {
+		if bc.wal == nil {
+			bc.wal, err = openWAL(bc.walOpts)
+			if err != nil {
+				return fmt.Errorf("opening WAL: %w", err)
+			}
 
-	go bc.flushLoop()
+			// A frame evicted before it was ever shipped (WALOverflowDropOldest)
+			// must still unblock whichever Send is waiting on its ack, rather
+			// than hang forever.
+			bc.wal.onEvict = func(seq uint64) {
+				bc.resolveWALAck(seq, errors.New("wal: entry evicted by overflow policy before it could be delivered"))
+			}
+		}
+
+		if bc.walAcks == nil {
+			bc.walAcks = make(map[uint64]chan error)
+		}
+
+		bc.walNotify = make(chan struct{}, 1)
+		bc.walStop = make(chan struct{})
+		bc.walDone = make(chan struct{})
+
+		go bc.walShipLoop()
+
+		// Wake the shipper to replay whatever was left un-truncated from a
+		// previous run (or a previous connection). Connect does not wait for
+		// the replay to finish and new Sends may append meanwhile; the
+		// shipper works in sequence order, so they go out after it.
+		select {
+		case bc.walNotify <- struct{}{}:
+		default:
+		}
+	}
 
 	return nil
 }
 
+// walShipLoop is the background shipper: it drains un-truncated WAL entries
+// in sequence order, writes them over the live connection, and advances the
+// truncation point once delivery is confirmed (an ack, or a successful
+// Flush when acks aren't required).
+func (bc *BufferedClient) walShipLoop() {
+	defer close(bc.walDone)
+
+	for {
+		select {
+		case <-bc.walNotify:
+			bc.shipWAL()
+		case <-bc.walStop:
+			return
+		}
+	}
+}
+
+func (bc *BufferedClient) shipWAL() {
+	entries, err := bc.wal.replay()
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		chunk, data := decodeWALFrame(entry.data)
+
+		// Route through the same writer goroutine as live Send/SendRaw
+		// calls use, so a replay can never race with a concurrent send.
+		// Force a flush: an acked entry must hit the wire before we wait on
+		// its ack, and a non-acked entry must hit the wire before we treat
+		// it as delivered and truncate it.
+		if err := bc.enqueueWrite(data, true); err != nil {
+			// Leave this and later entries in the log; they'll be retried
+			// on the next notify (e.g. after a reconnect).
+			return
+		}
+
+		if chunk != "" {
+			ackErr := bc.checkAck(chunk)
+			bc.resolveWALAck(entry.seq, ackErr)
+
+			if ackErr != nil {
+				// Don't truncate: the entry is still un-acked and must be
+				// retried. A failed ack wait means the connection is most
+				// likely gone, so kick off the same reconnect path a write
+				// failure would.
+				bc.onWriteError(ackErr)
+				return
+			}
+		}
+
+		if err := bc.wal.truncateFront(entry.seq); err != nil {
+			return
+		}
+	}
+}
+
 func (bc *BufferedClient) disconnect() (err error) {
 	return bc.Client.disconnect()
 }
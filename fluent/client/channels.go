@@ -0,0 +1,312 @@
+package client
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/IBM/fluent-forward-go/fluent/protocol"
+)
+
+// defaultChannelTag names the channel that Send/SendRaw/SendCompressed and
+// friends use, so existing callers that never heard of channels keep their
+// current behavior.
+const defaultChannelTag = "default"
+
+// ChannelDescriptor configures one named send channel, analogous to
+// tendermint's p2p ChannelDescriptor: a tag gets its own bounded queue and a
+// scheduling priority so a noisy low-priority tag can't starve a
+// high-priority one. Unlike tendermint's channels, a BufferedClient channel
+// is send-only - fluent-forward has no per-tag receive path - so there's no
+// RecvMessageCapacity equivalent here.
+type ChannelDescriptor struct {
+	Tag               string
+	Priority          int
+	SendQueueCapacity int
+}
+
+// sendChannel is the runtime state backing one ChannelDescriptor: its queue
+// plus the bookkeeping the writer goroutine's weighted round-robin
+// scheduler needs.
+type sendChannel struct {
+	desc          ChannelDescriptor
+	queue         chan queuedFrame
+	weight        int
+	currentWeight int // only ever touched by writerLoop
+}
+
+func newSendChannel(desc ChannelDescriptor, defaultCapacity int) *sendChannel {
+	capacity := desc.SendQueueCapacity
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+
+	weight := desc.Priority
+	if weight < 1 {
+		weight = 1
+	}
+
+	return &sendChannel{
+		desc:   desc,
+		queue:  make(chan queuedFrame, capacity),
+		weight: weight,
+	}
+}
+
+// registerChannels sets up the default channel plus any additional channels
+// from BufferedClientConnectionOptions.Channels. A Channels entry tagged
+// "default" overrides the built-in default's priority/capacity.
+func (bc *BufferedClient) registerChannels(opts BufferedClientConnectionOptions) {
+	bc.channels = map[string]*sendChannel{}
+	bc.channelOrder = nil
+
+	bc.channelsMu.Lock()
+	defer bc.channelsMu.Unlock()
+
+	defaultDesc := ChannelDescriptor{Tag: defaultChannelTag, Priority: 1}
+	for _, d := range opts.Channels {
+		if d.Tag == defaultChannelTag {
+			defaultDesc = d
+			break
+		}
+	}
+
+	bc.registerChannelLocked(defaultDesc, opts.SendQueueCapacity)
+
+	for _, d := range opts.Channels {
+		bc.registerChannelLocked(d, opts.SendQueueCapacity)
+	}
+
+	bc.defaultChannel = bc.channels[defaultChannelTag]
+}
+
+func (bc *BufferedClient) registerChannelLocked(desc ChannelDescriptor, defaultCapacity int) *sendChannel {
+	if existing, ok := bc.channels[desc.Tag]; ok {
+		return existing
+	}
+
+	ch := newSendChannel(desc, defaultCapacity)
+	bc.channels[desc.Tag] = ch
+	bc.channelOrder = append(bc.channelOrder, desc.Tag)
+
+	return ch
+}
+
+// channelFor returns the named channel, registering it on the fly with the
+// given priority if it hasn't been declared via
+// BufferedClientConnectionOptions.Channels.
+func (bc *BufferedClient) channelFor(tag string, priority int) *sendChannel {
+	bc.channelsMu.Lock()
+	defer bc.channelsMu.Unlock()
+
+	if ch, ok := bc.channels[tag]; ok {
+		return ch
+	}
+
+	return bc.registerChannelLocked(ChannelDescriptor{Tag: tag, Priority: priority}, defaultSendQueueCapacity)
+}
+
+// queueDepth sums the number of frames currently queued across every
+// channel, for the send_queue_depth gauge.
+func (bc *BufferedClient) queueDepth() int64 {
+	bc.channelsMu.RLock()
+	defer bc.channelsMu.RUnlock()
+
+	var depth int64
+
+	for _, tag := range bc.channelOrder {
+		depth += int64(len(bc.channels[tag].queue))
+	}
+
+	return depth
+}
+
+// SendOn encodes e and enqueues it onto tag's channel instead of the
+// default one, so it's scheduled according to that channel's priority.
+func (bc *BufferedClient) SendOn(tag string, e protocol.ChunkEncoder) error {
+	data, err := encodeToBytes(e)
+	if err != nil {
+		return err
+	}
+
+	if err := bc.rateLimiter.wait(len(data), bc.Client.Timeout); err != nil {
+		return err
+	}
+
+	ch := bc.channelFor(tag, 0)
+
+	return bc.enqueueWriteOn(ch, data, false)
+}
+
+// SendMessageOn enqueues record as a fluent-forward Message onto tag's
+// channel, registering the channel with the given priority if it doesn't
+// already exist.
+func (bc *BufferedClient) SendMessageOn(tag string, priority int, record interface{}) error {
+	msg := &protocol.Message{
+		Tag:     tag,
+		Time:    protocol.EventTime{Time: time.Now()},
+		Record:  record,
+		Options: &protocol.MessageOptions{},
+	}
+
+	data, err := encodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := bc.rateLimiter.wait(len(data), bc.Client.Timeout); err != nil {
+		return err
+	}
+
+	ch := bc.channelFor(tag, priority)
+
+	return bc.enqueueWriteOn(ch, data, false)
+}
+
+// writerLoop is the single goroutine permitted to call Write/Flush on the
+// current writer. Every Send/SendRaw/Sync/SendOn/SendMessageOn call hands
+// its work here instead of writing directly, which is what closes the race
+// between concurrent senders and the periodic flush. Reconnect swaps the
+// writer itself out from under this loop via currentWriter() rather than
+// restarting it, so a reconnect never races a frame mid-flight. Frames are
+// scheduled across channels with smooth weighted round robin, so a channel
+// with a higher Priority gets a proportionally larger share of the writer's
+// attention.
+func (bc *BufferedClient) writerLoop() {
+	defer close(bc.done)
+
+	for {
+		select {
+		case <-bc.stop:
+			bc.drainChannels()
+			return
+		case <-bc.ticker.C:
+			bc.flushWriter()
+			continue
+		default:
+		}
+
+		if ch := bc.pickChannel(); ch != nil {
+			select {
+			case frame := <-ch.queue:
+				bc.processFrame(frame)
+			default:
+				// submitToChannel's DropOldest path can race us for this
+				// frame (it evicts the queue head on overflow), so an empty
+				// read here is possible; just don't spin if it happens.
+			}
+
+			continue
+		}
+
+		if bc.waitForWork() {
+			return
+		}
+	}
+}
+
+// pickChannel runs one round of smooth weighted round robin over the
+// registered channels and returns the channel to service next, or nil if
+// none currently have a frame waiting.
+func (bc *BufferedClient) pickChannel() *sendChannel {
+	bc.channelsMu.RLock()
+	defer bc.channelsMu.RUnlock()
+
+	var (
+		best  *sendChannel
+		total int
+	)
+
+	for _, tag := range bc.channelOrder {
+		ch := bc.channels[tag]
+
+		if len(ch.queue) == 0 {
+			// An idle channel sits out this round entirely - it must not
+			// accrue scheduling credit it didn't earn, or it'll burst-
+			// dominate the writer for many rounds once it finally has work.
+			continue
+		}
+
+		ch.currentWeight += ch.weight
+		total += ch.weight
+
+		if best == nil || ch.currentWeight > best.currentWeight {
+			best = ch
+		}
+	}
+
+	if best != nil {
+		best.currentWeight -= total
+	}
+
+	return best
+}
+
+// waitForWork blocks until a frame arrives on any channel, the flush ticker
+// fires, or Stop is called, processing whichever happens first. It returns
+// true once the writer loop should exit.
+func (bc *BufferedClient) waitForWork() bool {
+	bc.channelsMu.RLock()
+	tags := append([]string(nil), bc.channelOrder...)
+	cases := make([]reflect.SelectCase, 0, len(tags)+2)
+
+	for _, tag := range tags {
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(bc.channels[tag].queue),
+		})
+	}
+	bc.channelsMu.RUnlock()
+
+	tickerIdx := len(cases)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(bc.ticker.C)})
+	stopIdx := len(cases)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(bc.stop)})
+
+	chosen, recv, _ := reflect.Select(cases)
+
+	switch chosen {
+	case stopIdx:
+		bc.drainChannels()
+		return true
+	case tickerIdx:
+		bc.flushWriter()
+		return false
+	default:
+		bc.processFrame(recv.Interface().(queuedFrame))
+		return false
+	}
+}
+
+// drainChannels is given ShutdownTimeout to flush out whatever is left
+// queued, across every channel, when Stop is called.
+func (bc *BufferedClient) drainChannels() {
+	deadline := time.After(bc.shutdownTimeout)
+
+	bc.channelsMu.RLock()
+	tags := append([]string(nil), bc.channelOrder...)
+	bc.channelsMu.RUnlock()
+
+	for {
+		drained := true
+
+		for _, tag := range tags {
+			select {
+			case frame := <-bc.channels[tag].queue:
+				bc.processFrame(frame)
+				drained = false
+			default:
+			}
+		}
+
+		if drained {
+			bc.flushWriter()
+			return
+		}
+
+		select {
+		case <-deadline:
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import "testing"
+
+func TestPickChannelIdleChannelDoesNotAccrueCredit(t *testing.T) {
+	active := newSendChannel(ChannelDescriptor{Tag: "active", Priority: 1}, 4)
+	idle := newSendChannel(ChannelDescriptor{Tag: "idle", Priority: 1}, 4)
+
+	bc := &BufferedClient{
+		channels:     map[string]*sendChannel{"active": active, "idle": idle},
+		channelOrder: []string{"active", "idle"},
+	}
+
+	for i := 0; i < 5; i++ {
+		active.queue <- queuedFrame{data: []byte("x")}
+
+		ch := bc.pickChannel()
+		if ch != active {
+			t.Fatalf("round %d: pickChannel picked %q, want %q", i, ch.desc.Tag, active.desc.Tag)
+		}
+
+		<-ch.queue // writerLoop would drain the picked frame before the next round
+	}
+
+	if idle.currentWeight != 0 {
+		t.Fatalf("idle channel's currentWeight = %d after sitting empty, want 0 (no unearned scheduling credit)", idle.currentWeight)
+	}
+}
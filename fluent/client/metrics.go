@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// clientMetrics holds the per-BufferedClient OpenTelemetry instruments. It
+// is safe to use with a nil meter: every method becomes a no-op so clients
+// that don't configure a MeterProvider pay no cost.
+type clientMetrics struct {
+	bytesWritten    metric.Int64Counter
+	flushLatency    metric.Float64Histogram
+	bufferOccupancy metric.Int64ObservableGauge
+	ackWaitLatency  metric.Float64Histogram
+	reconnectCount  metric.Int64Counter
+	sendQueueDepth  metric.Int64ObservableGauge
+}
+
+func newClientMetrics(provider metric.MeterProvider, bc *BufferedClient) *clientMetrics {
+	if provider == nil {
+		return &clientMetrics{}
+	}
+
+	meter := provider.Meter("github.com/IBM/fluent-forward-go/fluent/client")
+
+	m := &clientMetrics{}
+
+	m.bytesWritten, _ = meter.Int64Counter(
+		"fluent_forward.buffered_client.bytes_written",
+		metric.WithDescription("Total bytes written to the fluent-forward connection"),
+		metric.WithUnit("By"),
+	)
+
+	m.flushLatency, _ = meter.Float64Histogram(
+		"fluent_forward.buffered_client.flush_latency",
+		metric.WithDescription("Time taken to flush the write buffer"),
+		metric.WithUnit("ms"),
+	)
+
+	m.ackWaitLatency, _ = meter.Float64Histogram(
+		"fluent_forward.buffered_client.ack_wait_latency",
+		metric.WithDescription("Time spent waiting for an ack after Send"),
+		metric.WithUnit("ms"),
+	)
+
+	m.reconnectCount, _ = meter.Int64Counter(
+		"fluent_forward.buffered_client.reconnect_count",
+		metric.WithDescription("Number of times the client has reconnected"),
+	)
+
+	m.bufferOccupancy, _ = meter.Int64ObservableGauge(
+		"fluent_forward.buffered_client.buffer_occupancy",
+		metric.WithDescription("Approximate bytes currently sitting in the write buffer"),
+		metric.WithUnit("By"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(bc.bufferedBytes())
+			return nil
+		}),
+	)
+
+	m.sendQueueDepth, _ = meter.Int64ObservableGauge(
+		"fluent_forward.buffered_client.send_queue_depth",
+		metric.WithDescription("Frames queued across all send channels, awaiting the writer goroutine"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(bc.queueDepth())
+			return nil
+		}),
+	)
+
+	return m
+}
+
+func (m *clientMetrics) addBytesWritten(ctx context.Context, n int64) {
+	if m.bytesWritten != nil {
+		m.bytesWritten.Add(ctx, n)
+	}
+}
+
+func (m *clientMetrics) recordFlushLatency(ctx context.Context, ms float64) {
+	if m.flushLatency != nil {
+		m.flushLatency.Record(ctx, ms)
+	}
+}
+
+func (m *clientMetrics) recordAckWaitLatency(ctx context.Context, ms float64) {
+	if m.ackWaitLatency != nil {
+		m.ackWaitLatency.Record(ctx, ms)
+	}
+}
+
+func (m *clientMetrics) addReconnect(ctx context.Context) {
+	if m.reconnectCount != nil {
+		m.reconnectCount.Add(ctx, 1)
+	}
+}
+
+// bufferedBytes reports how many bytes are currently sitting unflushed in
+// the msgp writer. msgp.Writer doesn't expose this directly, so we track it
+// ourselves via unflushedBytes, updated alongside each write/flush.
+func (bc *BufferedClient) bufferedBytes() int64 {
+	return atomic.LoadInt64(&bc.unflushedBytes)
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestBufferOccupancyDecreasesOnFlush(t *testing.T) {
+	bc := NewBufferedClient(BufferedClientConnectionOptions{HighWaterMark: 1 << 20})
+
+	var buf bytes.Buffer
+	bc.writer.Store(msgp.NewWriterSize(&buf, defaultBufferSize))
+
+	bc.processFrame(queuedFrame{data: []byte("hello")})
+
+	if got := bc.bufferedBytes(); got != int64(len("hello")) {
+		t.Fatalf("bufferedBytes() after unflushed write = %d, want %d", got, len("hello"))
+	}
+
+	bc.processFrame(queuedFrame{flush: true})
+
+	if got := bc.bufferedBytes(); got != 0 {
+		t.Fatalf("bufferedBytes() after flush = %d, want 0", got)
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// SendOverflowPolicy decides what happens when a channel's send queue is
+// full at enqueue time.
+type SendOverflowPolicy int
+
+const (
+	// BlockWithTimeout waits up to EnqueueTimeout for room in the queue,
+	// applying backpressure to the caller.
+	BlockWithTimeout SendOverflowPolicy = iota
+	// DropNewest rejects the frame being enqueued, leaving the queue as-is.
+	DropNewest
+	// DropOldest evicts the head of the queue to make room for the new frame.
+	DropOldest
+)
+
+// ErrQueueFull is returned by Send/SendRaw when the send queue is full and
+// OverflowPolicy is DropNewest.
+var ErrQueueFull = errors.New("client: send queue is full")
+
+// ErrFrameDropped is delivered to a frame's done channel when OverflowPolicy
+// is DropOldest and the frame is evicted from the queue before the writer
+// goroutine ever sees it.
+var ErrFrameDropped = errors.New("client: frame evicted from send queue by DropOldest overflow policy")
+
+const (
+	defaultSendQueueCapacity = 1024
+	defaultShutdownTimeout   = 5 * time.Second
+)
+
+// queuedFrame is a unit of work handed to the single writer goroutine. A
+// nil data with flush set is a flush-only control frame, used by Sync().
+type queuedFrame struct {
+	data  []byte
+	flush bool
+	done  chan error
+}
+
+func (bc *BufferedClient) processFrame(frame queuedFrame) {
+	var err error
+
+	writer := bc.currentWriter()
+
+	if len(frame.data) > 0 {
+		var n int
+		n, err = writer.Write(frame.data)
+
+		bc.metrics.addBytesWritten(bc.metricsCtx(), int64(n))
+		atomic.AddInt64(&bc.unflushedBytes, int64(n))
+	}
+
+	if err == nil && (frame.flush || (bc.highWaterMark > 0 && atomic.LoadInt64(&bc.unflushedBytes) >= int64(bc.highWaterMark))) {
+		start := time.Now()
+		err = writer.Flush()
+		bc.metrics.recordFlushLatency(bc.metricsCtx(), float64(time.Since(start).Milliseconds()))
+		atomic.StoreInt64(&bc.unflushedBytes, 0)
+	}
+
+	if err != nil {
+		bc.onWriteError(err)
+	}
+
+	if frame.done != nil {
+		frame.done <- err
+	}
+}
+
+func (bc *BufferedClient) flushWriter() {
+	if atomic.LoadInt64(&bc.unflushedBytes) == 0 {
+		return
+	}
+
+	start := time.Now()
+	err := bc.currentWriter().Flush()
+	bc.metrics.recordFlushLatency(bc.metricsCtx(), float64(time.Since(start).Milliseconds()))
+
+	if err == nil {
+		atomic.StoreInt64(&bc.unflushedBytes, 0)
+	} else {
+		bc.onWriteError(err)
+	}
+}
+
+// submitToChannel hands a frame to ch's queue, applying the client's
+// configured overflow policy if the queue is full.
+func (bc *BufferedClient) submitToChannel(ch *sendChannel, frame queuedFrame) error {
+	switch bc.overflowPolicy {
+	case DropNewest:
+		select {
+		case ch.queue <- frame:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	case DropOldest:
+		for {
+			select {
+			case ch.queue <- frame:
+				return nil
+			default:
+				select {
+				case evicted := <-ch.queue:
+					if evicted.done != nil {
+						evicted.done <- ErrFrameDropped
+					}
+				default:
+				}
+			}
+		}
+	default: // BlockWithTimeout
+		if bc.enqueueTimeout <= 0 {
+			ch.queue <- frame
+			return nil
+		}
+
+		timer := time.NewTimer(bc.enqueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case ch.queue <- frame:
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("client: send queue %q enqueue timed out after %s", ch.desc.Tag, bc.enqueueTimeout)
+		}
+	}
+}
+
+// enqueueFlush submits a flush-only control frame to the default channel
+// and waits for the writer goroutine to act on it, used by Sync().
+func (bc *BufferedClient) enqueueFlush() error {
+	done := make(chan error, 1)
+
+	if err := bc.submitToChannel(bc.defaultChannel, queuedFrame{flush: true, done: done}); err != nil {
+		return err
+	}
+
+	return <-done
+}
+
+// enqueueWrite submits data to the default channel to be written, optionally
+// flushed immediately (e.g. for RequireAck sends, which must hit the wire
+// before we can wait for the corresponding ack).
+func (bc *BufferedClient) enqueueWrite(data []byte, flush bool) error {
+	return bc.enqueueWriteOn(bc.defaultChannel, data, flush)
+}
+
+// enqueueWriteOn is enqueueWrite against an arbitrary channel, used by
+// SendOn/SendMessageOn.
+func (bc *BufferedClient) enqueueWriteOn(ch *sendChannel, data []byte, flush bool) error {
+	done := make(chan error, 1)
+
+	if err := bc.submitToChannel(ch, queuedFrame{data: data, flush: flush, done: done}); err != nil {
+		return err
+	}
+
+	return <-done
+}
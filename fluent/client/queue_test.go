@@ -0,0 +1,43 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubmitToChannelDropOldestUnblocksEvictedCaller(t *testing.T) {
+	ch := newSendChannel(ChannelDescriptor{Tag: "t", SendQueueCapacity: 1}, defaultSendQueueCapacity)
+
+	bc := &BufferedClient{overflowPolicy: DropOldest}
+
+	evictedDone := make(chan error, 1)
+	if err := bc.submitToChannel(ch, queuedFrame{data: []byte("old"), done: evictedDone}); err != nil {
+		t.Fatalf("first submit: %v", err)
+	}
+
+	// The queue (capacity 1) is now full; this submit must evict the frame
+	// above rather than block forever.
+	newDone := make(chan error, 1)
+	if err := bc.submitToChannel(ch, queuedFrame{data: []byte("new"), done: newDone}); err != nil {
+		t.Fatalf("second submit: %v", err)
+	}
+
+	select {
+	case err := <-evictedDone:
+		if !errors.Is(err, ErrFrameDropped) {
+			t.Fatalf("evicted frame's done = %v, want ErrFrameDropped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("evicted frame's caller never unblocked")
+	}
+
+	select {
+	case frame := <-ch.queue:
+		if string(frame.data) != "new" {
+			t.Fatalf("queue head = %q, want %q", frame.data, "new")
+		}
+	default:
+		t.Fatal("expected the new frame to still be queued")
+	}
+}
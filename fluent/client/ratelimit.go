@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is returned by the send path when the configured rate
+// limit would block longer than the caller's context allows, so upstream
+// code can shed load instead of piling up behind a slow limiter.
+var ErrRateLimited = errors.New("client: rate limit exceeded")
+
+// rateLimiter enforces independent byte and message budgets on the send
+// path. A zero-value rateLimiter (both limiters nil) never limits.
+type rateLimiter struct {
+	bytes    *rate.Limiter
+	messages *rate.Limiter
+}
+
+func newRateLimiter(opts BufferedClientConnectionOptions) *rateLimiter {
+	if opts.BytesPerSecond == 0 && opts.MessagesPerSecond == 0 {
+		return nil
+	}
+
+	rl := &rateLimiter{}
+
+	if opts.BytesPerSecond > 0 {
+		burst := opts.BytesBurst
+		if burst == 0 {
+			burst = int(opts.BytesPerSecond)
+		}
+
+		rl.bytes = rate.NewLimiter(rate.Limit(opts.BytesPerSecond), burst)
+	}
+
+	if opts.MessagesPerSecond > 0 {
+		burst := opts.MessagesBurst
+		if burst == 0 {
+			burst = int(opts.MessagesPerSecond)
+		}
+
+		rl.messages = rate.NewLimiter(rate.Limit(opts.MessagesPerSecond), burst)
+	}
+
+	return rl
+}
+
+// wait blocks until n bytes and one message are permitted under the
+// configured budgets, bounded by timeout. It returns ErrRateLimited rather
+// than blocking past that deadline.
+func (rl *rateLimiter) wait(n int, timeout time.Duration) error {
+	if rl == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if rl.bytes != nil {
+		if err := rl.bytes.WaitN(ctx, n); err != nil {
+			return ErrRateLimited
+		}
+	}
+
+	if rl.messages != nil {
+		if err := rl.messages.WaitN(ctx, 1); err != nil {
+			return ErrRateLimited
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterNilWhenUnconfigured(t *testing.T) {
+	if rl := newRateLimiter(BufferedClientConnectionOptions{}); rl != nil {
+		t.Fatalf("newRateLimiter with no limits configured = %v, want nil", rl)
+	}
+}
+
+func TestRateLimiterWaitAllowsBurstThenRateLimits(t *testing.T) {
+	rl := newRateLimiter(BufferedClientConnectionOptions{
+		MessagesPerSecond: 1,
+		MessagesBurst:     1,
+	})
+
+	if err := rl.wait(0, time.Second); err != nil {
+		t.Fatalf("first wait (within burst) = %v, want nil", err)
+	}
+
+	if err := rl.wait(0, 10*time.Millisecond); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second wait (burst exhausted, short timeout) = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimiterWaitEnforcesBytesIndependentlyOfMessages(t *testing.T) {
+	rl := newRateLimiter(BufferedClientConnectionOptions{
+		BytesPerSecond: 10,
+		BytesBurst:     10,
+	})
+
+	if err := rl.wait(10, time.Second); err != nil {
+		t.Fatalf("wait within byte burst = %v, want nil", err)
+	}
+
+	if err := rl.wait(10, 10*time.Millisecond); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("wait exceeding byte budget = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestNilRateLimiterNeverBlocks(t *testing.T) {
+	var rl *rateLimiter
+
+	if err := rl.wait(1<<20, 0); err != nil {
+		t.Fatalf("nil rateLimiter.wait = %v, want nil", err)
+	}
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClientState describes where a BufferedClient sits in its connection
+// lifecycle: Connected -> Reconnecting -> Connected | Broken.
+type ClientState int32
+
+const (
+	StateConnected ClientState = iota
+	StateReconnecting
+	StateBroken
+)
+
+func (s ClientState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateBroken:
+		return "broken"
+	default:
+		return "unknown"
+	}
+}
+
+// RetryPolicy decides how long to wait before the next reconnect attempt.
+// Returning ok=false gives up, putting the client into StateBroken.
+type RetryPolicy interface {
+	NextBackoff(attempt int, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff doubles (times Factor) the delay on each attempt, up
+// to Max. MaxAttempts of 0 means retry forever.
+type ExponentialBackoff struct {
+	Base        time.Duration
+	Max         time.Duration
+	Factor      float64
+	MaxAttempts int
+}
+
+func (b ExponentialBackoff) NextBackoff(attempt int, _ error) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+
+	factor := b.Factor
+	if factor <= 1 {
+		factor = 2
+	}
+
+	delay := float64(b.Base) * pow(factor, attempt-1)
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	return time.Duration(delay), true
+}
+
+func pow(base float64, exp int) float64 {
+	result := 1.0
+	for i := 0; i < exp; i++ {
+		result *= base
+	}
+
+	return result
+}
+
+// JitteredBackoff wraps another RetryPolicy and randomizes its delay by up
+// to +/-Jitter (a fraction of the delay, e.g. 0.2 for +/-20%), to avoid
+// every client in a fleet reconnecting in lockstep.
+type JitteredBackoff struct {
+	Policy RetryPolicy
+	Jitter float64
+}
+
+func (b JitteredBackoff) NextBackoff(attempt int, err error) (time.Duration, bool) {
+	delay, ok := b.Policy.NextBackoff(attempt, err)
+	if !ok || delay == 0 {
+		return delay, ok
+	}
+
+	jitter := b.Jitter
+	if jitter <= 0 {
+		jitter = 0.2
+	}
+
+	spread := float64(delay) * jitter
+	offset := (rand.Float64()*2 - 1) * spread // nolint:gosec // jitter doesn't need CSPRNG
+
+	return time.Duration(float64(delay) + offset), true
+}
+
+const (
+	defaultRetryBase                  = 100 * time.Millisecond
+	defaultRetryMax                   = 30 * time.Second
+	defaultCircuitBreakerThreshold    = 5
+	defaultCircuitBreakerOpenDuration = 30 * time.Second
+)
+
+func defaultRetryPolicy() RetryPolicy {
+	return JitteredBackoff{
+		Policy: ExponentialBackoff{Base: defaultRetryBase, Max: defaultRetryMax, Factor: 2},
+	}
+}
+
+// circuitBreaker trips after a run of consecutive failures and stays open
+// for OpenDuration before letting a single half-open probe through.
+type circuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	openedAt            time.Time
+}
+
+func newCircuitBreaker(threshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openDuration: openDuration}
+}
+
+// allow reports whether a reconnect attempt may proceed right now.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.threshold <= 0 || cb.consecutiveFailures < cb.threshold {
+		return true
+	}
+
+	return time.Since(cb.openedAt) >= cb.openDuration
+}
+
+func (cb *circuitBreaker) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures = 0
+}
+
+func (cb *circuitBreaker) recordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures++
+	if cb.consecutiveFailures >= cb.threshold {
+		cb.openedAt = time.Now()
+	}
+}
+
+// State reports the client's current position in the connection lifecycle.
+func (bc *BufferedClient) State() ClientState {
+	return ClientState(atomic.LoadInt32((*int32)(&bc.state)))
+}
+
+// StateChanges returns a channel that receives every subsequent state
+// transition. The channel is buffered by one slot; a subscriber that falls
+// behind only sees the most recent state, not a backlog of every change.
+func (bc *BufferedClient) StateChanges() <-chan ClientState {
+	ch := make(chan ClientState, 1)
+
+	bc.stateSubsMu.Lock()
+	bc.stateSubs = append(bc.stateSubs, ch)
+	bc.stateSubsMu.Unlock()
+
+	return ch
+}
+
+func (bc *BufferedClient) setState(s ClientState) {
+	atomic.StoreInt32((*int32)(&bc.state), int32(s))
+
+	bc.stateSubsMu.Lock()
+	defer bc.stateSubsMu.Unlock()
+
+	for _, sub := range bc.stateSubs {
+		select {
+		case sub <- s:
+		default:
+			// Drain the stale value so the latest state always fits.
+			select {
+			case <-sub:
+			default:
+			}
+
+			select {
+			case sub <- s:
+			default:
+			}
+		}
+	}
+}
+
+// onWriteError is called by the writer goroutine (and the WAL shipper) when
+// a write or flush fails in a way that suggests the connection is gone. It
+// kicks off (at most one concurrent) reconnect attempt with backoff and
+// circuit-breaking.
+func (bc *BufferedClient) onWriteError(err error) {
+	if !atomic.CompareAndSwapInt32((*int32)(&bc.state), int32(StateConnected), int32(StateReconnecting)) {
+		return // already reconnecting, or given up as broken
+	}
+
+	bc.setState(StateReconnecting)
+
+	bc.reconnectWG.Add(1)
+
+	go func() {
+		defer bc.reconnectWG.Done()
+		bc.reconnectLoop(err)
+	}()
+}
+
+// reconnectLoop retries BufferedClient.Reconnect until it succeeds, the
+// policy gives up (-> StateBroken), or Stop is called. On success it wakes
+// the WAL shipper so any un-acked frames are redelivered.
+func (bc *BufferedClient) reconnectLoop(cause error) {
+	attempt := 0
+	lastErr := cause
+
+	for {
+		attempt++
+
+		if !bc.breaker.allow() {
+			if bc.sleepOrStop(bc.breaker.openDuration) {
+				return
+			}
+
+			continue
+		}
+
+		if err := bc.Reconnect(); err != nil {
+			bc.breaker.recordFailure()
+			lastErr = err
+
+			backoff, ok := bc.retryPolicy.NextBackoff(attempt, lastErr)
+			if !ok {
+				bc.setState(StateBroken)
+				return
+			}
+
+			if bc.sleepOrStop(backoff) {
+				return
+			}
+
+			continue
+		}
+
+		bc.breaker.recordSuccess()
+		bc.setState(StateConnected)
+
+		if bc.walEnabled() {
+			select {
+			case bc.walNotify <- struct{}{}:
+			default:
+			}
+		}
+
+		return
+	}
+}
+
+// sleepOrStop waits out d, or returns true early if Stop was called.
+func (bc *BufferedClient) sleepOrStop(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return false
+	case <-bc.reconnectStop:
+		return true
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffDoublesUpToMax(t *testing.T) {
+	b := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second, Factor: 2}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second}, // capped at Max
+	}
+
+	for _, c := range cases {
+		got, ok := b.NextBackoff(c.attempt, nil)
+		if !ok {
+			t.Fatalf("attempt %d: ok = false, want true", c.attempt)
+		}
+
+		if got != c.want {
+			t.Fatalf("attempt %d: backoff = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestExponentialBackoffGivesUpAfterMaxAttempts(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Millisecond, MaxAttempts: 3}
+
+	if _, ok := b.NextBackoff(3, nil); !ok {
+		t.Fatal("attempt 3 (== MaxAttempts) should still be allowed")
+	}
+
+	if _, ok := b.NextBackoff(4, nil); ok {
+		t.Fatal("attempt 4 (> MaxAttempts) should give up")
+	}
+}
+
+func TestJitteredBackoffStaysWithinSpread(t *testing.T) {
+	b := JitteredBackoff{Policy: ExponentialBackoff{Base: time.Second, Factor: 1}, Jitter: 0.5}
+
+	for i := 0; i < 20; i++ {
+		got, ok := b.NextBackoff(1, nil)
+		if !ok {
+			t.Fatal("expected ok = true")
+		}
+
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("jittered backoff = %v, want within [0.5s, 1.5s]", got)
+		}
+	}
+}
+
+func TestCircuitBreakerOpensAtThresholdAndHalfOpensAfterDuration(t *testing.T) {
+	cb := newCircuitBreaker(2, 20*time.Millisecond)
+
+	if !cb.allow() {
+		t.Fatal("fresh breaker should allow")
+	}
+
+	cb.recordFailure()
+	if !cb.allow() {
+		t.Fatal("breaker should still allow below threshold")
+	}
+
+	cb.recordFailure()
+	if cb.allow() {
+		t.Fatal("breaker should be open once consecutive failures hit threshold")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if !cb.allow() {
+		t.Fatal("breaker should allow a half-open probe once openDuration has passed")
+	}
+
+	cb.recordSuccess()
+	if !cb.allow() {
+		t.Fatal("breaker should allow freely again after a recorded success")
+	}
+}
+
+func TestClientStateString(t *testing.T) {
+	cases := map[ClientState]string{
+		StateConnected:    "connected",
+		StateReconnecting: "reconnecting",
+		StateBroken:       "broken",
+		ClientState(99):   "unknown",
+	}
+
+	for state, want := range cases {
+		if got := state.String(); got != want {
+			t.Fatalf("%d.String() = %q, want %q", int32(state), got, want)
+		}
+	}
+}
+
+func TestStateChangesDeliversLatestOnly(t *testing.T) {
+	bc := &BufferedClient{}
+
+	sub := bc.StateChanges()
+
+	bc.setState(StateReconnecting)
+	bc.setState(StateBroken)
+
+	select {
+	case s := <-sub:
+		if s != StateBroken {
+			t.Fatalf("StateChanges delivered %v, want %v (latest should win over a full buffer)", s, StateBroken)
+		}
+	default:
+		t.Fatal("expected a state change to be available on the subscription channel")
+	}
+
+	if got := bc.State(); got != StateBroken {
+		t.Fatalf("State() = %v, want %v", got, StateBroken)
+	}
+}
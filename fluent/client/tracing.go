@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// metricsCtx is the context passed to instrument calls that don't have a
+// caller-supplied context to propagate (the writer goroutine, timers, etc).
+func (bc *BufferedClient) metricsCtx() context.Context {
+	return context.Background()
+}
+
+// tracer returns the client's configured tracer, falling back to a no-op
+// tracer when no TracerProvider was supplied, so call sites never need a
+// nil check.
+func (bc *BufferedClient) tracer() trace.Tracer {
+	provider := bc.tracerProvider
+	if provider == nil {
+		provider = trace.NewNoopTracerProvider()
+	}
+
+	return provider.Tracer("github.com/IBM/fluent-forward-go/fluent/client")
+}
+
+// startSpan starts a span for a Send-family operation, tagged with enough
+// attributes to diagnose a slow or failing flush without reading logs.
+func (bc *BufferedClient) startSpan(
+	ctx context.Context,
+	name string,
+	tag string,
+	entryCount int,
+	byteCount int,
+) (context.Context, trace.Span) {
+	return bc.tracer().Start(ctx, name, trace.WithAttributes(
+		attribute.String("fluent_forward.tag", tag),
+		attribute.Int("fluent_forward.entry_count", entryCount),
+		attribute.Int("fluent_forward.byte_count", byteCount),
+		attribute.Bool("fluent_forward.require_ack", bc.Client.RequireAck),
+	))
+}
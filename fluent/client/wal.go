@@ -0,0 +1,295 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tidwall/wal"
+)
+
+// WALSyncPolicy controls how aggressively the write-ahead log is fsynced.
+type WALSyncPolicy int
+
+const (
+	// SyncEveryWrite fsyncs the WAL after every append. Safest, slowest.
+	SyncEveryWrite WALSyncPolicy = iota
+	// SyncInterval fsyncs the WAL on a fixed interval (see WALOptions.SyncInterval).
+	SyncInterval
+	// SyncNone never explicitly fsyncs; the OS decides when pages are flushed.
+	SyncNone
+)
+
+// WALOverflowPolicy decides what happens when a WAL hits WALOptions.MaxBytes.
+type WALOverflowPolicy int
+
+const (
+	// WALOverflowBlock applies backpressure by refusing the write: an append
+	// that would exceed MaxBytes fails with an error, until the shipper
+	// truncates enough of the log to make room.
+	WALOverflowBlock WALOverflowPolicy = iota
+	// WALOverflowDropOldest truncates the oldest un-shipped entries to make
+	// room for new writes, sacrificing durability for availability.
+	WALOverflowDropOldest
+)
+
+const (
+	defaultWALSegmentSize  = 20 * 1024 * 1024 // 20 MB, matches tidwall/wal's default
+	defaultWALSyncInterval = time.Second
+)
+
+// WALOptions configures the optional durable write-ahead log backing a
+// BufferedClient. When Dir is empty, no WAL is used and Send/SendRaw behave
+// exactly as before.
+type WALOptions struct {
+	Dir            string
+	SegmentSize    int
+	MaxBytes       int64
+	SyncPolicy     WALSyncPolicy
+	SyncInterval   time.Duration
+	OverflowPolicy WALOverflowPolicy
+}
+
+// walEntry is a single chunk awaiting shipment, recorded in the WAL under
+// its sequence number.
+type walEntry struct {
+	seq  uint64
+	data []byte
+}
+
+// bufferedWAL wraps a tidwall/wal.Log with the bookkeeping BufferedClient
+// needs: a shipped-up-to cursor, a byte-budget for overflow handling, and
+// the sync policy ticker.
+type bufferedWAL struct {
+	opts   WALOptions
+	log    *wal.Log
+	mutex  sync.Mutex
+	cursor uint64 // last sequence number successfully shipped and truncated
+	bytes  int64  // approximate bytes currently retained in the log
+
+	syncTicker *time.Ticker
+	syncStop   chan struct{}
+	syncDone   chan struct{}
+
+	// onEvict, if set, is called with the sequence number of every entry
+	// truncateFrontLocked drops, including ones evicted by WALOverflowDropOldest
+	// before they were ever shipped. BufferedClient uses it to unblock a
+	// Send that's waiting on that entry's ack.
+	onEvict func(seq uint64)
+}
+
+func openWAL(opts WALOptions) (*bufferedWAL, error) {
+	if opts.SegmentSize == 0 {
+		opts.SegmentSize = defaultWALSegmentSize
+	}
+
+	if opts.SyncPolicy == SyncInterval && opts.SyncInterval == 0 {
+		opts.SyncInterval = defaultWALSyncInterval
+	}
+
+	log, err := wal.Open(opts.Dir, &wal.Options{
+		SegmentSize: opts.SegmentSize,
+		NoSync:      opts.SyncPolicy == SyncNone || opts.SyncPolicy == SyncInterval,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening WAL at %q: %w", opts.Dir, err)
+	}
+
+	bw := &bufferedWAL{
+		opts: opts,
+		log:  log,
+	}
+
+	first, err := log.FirstIndex()
+	if err != nil {
+		return nil, err
+	}
+	// Everything before the first retained index has been truncated; the
+	// first retained entry itself is shipped again, at least once.
+	if first > 0 {
+		bw.cursor = first - 1
+	}
+
+	if opts.SyncPolicy == SyncInterval {
+		bw.syncTicker = time.NewTicker(opts.SyncInterval)
+		bw.syncStop = make(chan struct{})
+		bw.syncDone = make(chan struct{})
+
+		go bw.syncLoop()
+	}
+
+	return bw, nil
+}
+
+func (bw *bufferedWAL) syncLoop() {
+	defer close(bw.syncDone)
+
+	for {
+		select {
+		case <-bw.syncTicker.C:
+			bw.mutex.Lock()
+			_ = bw.log.Sync()
+			bw.mutex.Unlock()
+		case <-bw.syncStop:
+			return
+		}
+	}
+}
+
+// append writes data to the log under the next sequence number, applying
+// the configured overflow policy if MaxBytes would be exceeded.
+func (bw *bufferedWAL) append(data []byte) (uint64, error) {
+	bw.mutex.Lock()
+	defer bw.mutex.Unlock()
+
+	if bw.opts.MaxBytes > 0 && bw.bytes+int64(len(data)) > bw.opts.MaxBytes {
+		switch bw.opts.OverflowPolicy {
+		case WALOverflowDropOldest:
+			if err := bw.evictUntilUnderBudgetLocked(int64(len(data))); err != nil {
+				return 0, err
+			}
+		default: // WALOverflowBlock
+			return 0, fmt.Errorf("wal: MaxBytes (%d) exceeded, backpressure applied", bw.opts.MaxBytes)
+		}
+	}
+
+	last, err := bw.log.LastIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	seq := last + 1
+	if err := bw.log.Write(seq, data); err != nil {
+		return 0, err
+	}
+
+	bw.bytes += int64(len(data))
+
+	if bw.opts.SyncPolicy == SyncEveryWrite {
+		if err := bw.log.Sync(); err != nil {
+			return 0, err
+		}
+	}
+
+	return seq, nil
+}
+
+// evictUntilUnderBudgetLocked truncates the oldest un-shipped entries, one
+// at a time, until an append of incoming bytes would fit within MaxBytes.
+// Once nothing is left after the cursor to evict, it stops and lets the
+// append through oversized rather than spinning forever.
+func (bw *bufferedWAL) evictUntilUnderBudgetLocked(incoming int64) error {
+	for bw.bytes+incoming > bw.opts.MaxBytes {
+		last, err := bw.log.LastIndex()
+		if err != nil {
+			return err
+		}
+
+		if bw.cursor >= last {
+			return nil
+		}
+
+		if err := bw.truncateFrontLocked(bw.cursor + 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replay returns every un-truncated entry in sequence order, for the
+// shipper to resend after a reconnect.
+func (bw *bufferedWAL) replay() ([]walEntry, error) {
+	bw.mutex.Lock()
+	defer bw.mutex.Unlock()
+
+	first, err := bw.log.FirstIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := bw.log.LastIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	// tidwall/wal's TruncateFront keeps the entry at its index, so entries
+	// up to the cursor may still be in the log; they are not returned.
+	if first <= bw.cursor {
+		first = bw.cursor + 1
+	}
+
+	if last < first {
+		return nil, nil
+	}
+
+	entries := make([]walEntry, 0, last-first+1)
+
+	for seq := first; seq <= last && seq != 0; seq++ {
+		data, err := bw.log.Read(seq)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, walEntry{seq: seq, data: data})
+	}
+
+	return entries, nil
+}
+
+// truncateFront drops every entry up to and including upTo, advancing the
+// shipped cursor. Callers use this once a frame has been acked/flushed.
+func (bw *bufferedWAL) truncateFront(upTo uint64) error {
+	bw.mutex.Lock()
+	defer bw.mutex.Unlock()
+
+	return bw.truncateFrontLocked(upTo)
+}
+
+func (bw *bufferedWAL) truncateFrontLocked(upTo uint64) error {
+	if upTo <= bw.cursor {
+		return nil
+	}
+
+	var removed int64
+
+	for seq := bw.cursor + 1; seq <= upTo; seq++ {
+		data, err := bw.log.Read(seq)
+		if err != nil {
+			if err == wal.ErrNotFound {
+				continue
+			}
+
+			return err
+		}
+
+		removed += int64(len(data))
+
+		if bw.onEvict != nil {
+			bw.onEvict(seq)
+		}
+	}
+
+	if err := bw.log.TruncateFront(upTo); err != nil && err != wal.ErrOutOfRange {
+		return err
+	}
+
+	bw.cursor = upTo
+	bw.bytes -= removed
+
+	if bw.bytes < 0 {
+		bw.bytes = 0
+	}
+
+	return nil
+}
+
+func (bw *bufferedWAL) Close() error {
+	if bw.syncTicker != nil {
+		bw.syncTicker.Stop()
+		close(bw.syncStop)
+		<-bw.syncDone
+	}
+
+	return bw.log.Close()
+}
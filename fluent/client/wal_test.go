@@ -0,0 +1,110 @@
+package client
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBufferedWALMaxBytesDropOldestEvictsUntilUnderBudget(t *testing.T) {
+	bw, err := openWAL(WALOptions{
+		Dir:            filepath.Join(t.TempDir(), "wal"),
+		MaxBytes:       25,
+		OverflowPolicy: WALOverflowDropOldest,
+	})
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+	defer bw.Close()
+
+	// Five 10-byte entries in a row should never be allowed to push the log
+	// past MaxBytes, no matter how
+	// far behind the (non-existent, in this test) shipper is.
+	for i := 0; i < 5; i++ {
+		if _, err := bw.append([]byte("0123456789")); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+
+		if bw.bytes > bw.opts.MaxBytes {
+			t.Fatalf("after append %d: bytes = %d, want <= MaxBytes (%d)", i, bw.bytes, bw.opts.MaxBytes)
+		}
+	}
+
+	entries, err := bw.replay()
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	var gotBytes int64
+	for _, e := range entries {
+		gotBytes += int64(len(e.data))
+	}
+
+	if gotBytes != bw.bytes {
+		t.Fatalf("bw.bytes = %d, but replay only found %d bytes still retained", bw.bytes, gotBytes)
+	}
+}
+
+func TestBufferedWALMaxBytesBlockRejectsOverflowingAppend(t *testing.T) {
+	bw, err := openWAL(WALOptions{
+		Dir:            filepath.Join(t.TempDir(), "wal"),
+		MaxBytes:       15,
+		OverflowPolicy: WALOverflowBlock,
+	})
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+	defer bw.Close()
+
+	if _, err := bw.append([]byte("0123456789")); err != nil {
+		t.Fatalf("first append: %v", err)
+	}
+
+	if _, err := bw.append([]byte("0123456789")); err == nil {
+		t.Fatal("expected second append to be rejected once MaxBytes would be exceeded")
+	}
+}
+
+func TestBufferedWALTruncateFrontInvokesOnEvict(t *testing.T) {
+	bw, err := openWAL(WALOptions{Dir: filepath.Join(t.TempDir(), "wal")})
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+	defer bw.Close()
+
+	var evicted []uint64
+	bw.onEvict = func(seq uint64) {
+		evicted = append(evicted, seq)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := bw.append([]byte("x")); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	if err := bw.truncateFront(2); err != nil {
+		t.Fatalf("truncateFront: %v", err)
+	}
+
+	if want := []uint64{1, 2}; !equalUint64s(evicted, want) {
+		t.Fatalf("onEvict calls = %v, want %v", evicted, want)
+	}
+
+	if bw.bytes != 1 {
+		t.Fatalf("bw.bytes = %d, want 1 (one 1-byte entry left)", bw.bytes)
+	}
+}
+
+func equalUint64s(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}